@@ -0,0 +1,23 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ifc holds miscellaneous interfaces used by kustomize.
+package ifc
+
+// Loader interface exposes methods to read bytes.
+type Loader interface {
+	// Repo returns the repo location if this Loader was created from a
+	// url pointing to a remote repo, or the empty string otherwise.
+	Repo() string
+	// Root returns the absolute path that Load and New are relative to.
+	Root() string
+	// New returns a Loader relative to this one, rooted at the given
+	// location. The location can be a relative or absolute path, a URL
+	// to a git repo, or a URL to a supported archive.
+	New(path string) (Loader, error)
+	// Load returns the bytes at the given location, relative to Root().
+	Load(path string) ([]byte, error)
+	// Cleanup releases any resources (e.g. temporary clone directories)
+	// held by this Loader or any Loader it spawned via New.
+	Cleanup() error
+}
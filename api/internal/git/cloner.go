@@ -0,0 +1,25 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// Cloner clones the repository identified by spec to a local directory
+// and records that directory in spec.Dir. Implementations differ in how
+// they perform the clone (shell out to git, use an in-process library)
+// and in what they do with spec.Ref/Depth/Submodules.
+type Cloner func(spec *RepoSpec) error
+
+// DoNothingCloner returns a Cloner that, instead of actually cloning
+// anything, pretends every RepoSpec was already cloned to dir. It's
+// meant for tests that want to exercise the loader's URL and root
+// handling without touching the network or the file system.
+func DoNothingCloner(dir filesys.ConfirmedDir) Cloner {
+	return func(spec *RepoSpec) error {
+		spec.Dir = dir
+		return nil
+	}
+}
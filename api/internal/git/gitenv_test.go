@@ -0,0 +1,33 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// TestGitEnvIsolation checks that IsolatedGitConfig actually changes
+// the environment a clone subprocess runs in, rather than just
+// documenting that it should.
+func TestGitEnvIsolation(t *testing.T) {
+	req := require.New(t)
+	dir := filesys.ConfirmedDir("/tmp/someClone")
+
+	plain := gitEnv(ClonerOptions{}, dir)
+	req.Equal(os.Environ(), plain)
+	for _, kv := range plain {
+		req.False(strings.HasPrefix(kv, "GIT_CONFIG_GLOBAL="), "unisolated env should not set %s", kv)
+	}
+
+	isolated := gitEnv(ClonerOptions{IsolatedGitConfig: true}, dir)
+	req.Contains(isolated, "GIT_CONFIG_GLOBAL=/dev/null")
+	req.Contains(isolated, "GIT_CONFIG_SYSTEM=/dev/null")
+	req.Contains(isolated, "GIT_TERMINAL_PROMPT=0")
+	req.Contains(isolated, "HOME="+string(dir))
+}
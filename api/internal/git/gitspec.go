@@ -0,0 +1,247 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package git parses git-url "bases" (as used in a kustomization's
+// `resources` or `bases` field) and knows how to clone them.
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// knownHosts lists hosts for which exactly two path segments
+// (org/repo) are known to identify the repository, with everything
+// after that being a path inside the repo.  This lets a URL like
+// github.com/kubernetes-sigs/kustomize/api be split into the repo
+// "kubernetes-sigs/kustomize" and the in-repo path "/api" without
+// requiring a ".git" suffix.
+var knownHosts = []string{
+	"github.com/",
+	"gitlab.com/",
+	"bitbucket.org/",
+}
+
+const (
+	gitSuffix  = ".git"
+	refQuery   = "ref"
+	depthQuery = "depth"
+	submQuery  = "submodules"
+)
+
+// RepoSpec specifies a git repository and a path and ref therein.
+type RepoSpec struct {
+	// Raw is the original, unparsed argument to NewRepoSpecFromURL.
+	Raw string
+
+	// Host is the git remote, e.g. "https://github.com/" or "git@github.com:".
+	Host string
+
+	// OrgRepo is the organization/repo pair, e.g. "kubernetes-sigs/kustomize".
+	OrgRepo string
+
+	// Path is the location of the kustomization root inside the repo,
+	// e.g. "/api". Empty if the repo root is the kustomization root.
+	Path string
+
+	// Ref is the git ref (branch, tag or commit) to check out. Empty
+	// means the repo's default branch.
+	Ref string
+
+	// Submodules controls whether submodules are fetched recursively.
+	// Defaults to true.
+	Submodules bool
+
+	// Depth, when greater than zero, requests a shallow clone of that
+	// depth instead of a full clone.
+	Depth int
+
+	// Timeout bounds how long the clone is allowed to take.
+	Timeout time.Duration
+
+	// Dir is the directory the clone landed in. Set by a Cloner, not by
+	// NewRepoSpecFromURL.
+	Dir filesys.ConfirmedDir
+}
+
+// CloneSpec returns the part of the original URL that identifies the
+// repository itself, i.e. everything but the in-repo path and ref.
+func (x *RepoSpec) CloneSpec() string {
+	return x.Host + x.OrgRepo
+}
+
+// AbsPath returns the absolute path, within the clone, of the
+// kustomization root this RepoSpec refers to. Only meaningful after a
+// Cloner has populated Dir.
+func (x *RepoSpec) AbsPath() string {
+	return x.Dir.Join(x.Path)
+}
+
+// NewRepoSpecFromURL parses n as a reference to a git repository. It
+// returns an error if n does not look like one, so that callers can
+// fall back to treating n as a local path.
+func NewRepoSpecFromURL(n string) (*RepoSpec, error) {
+	raw := n
+	n, fragRef, fragPath := splitFragment(n)
+	n, ref, depth, submodules := parseQuery(n)
+
+	host, orgRepo, path, err := splitHostOrgRepoPath(n)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Docker-style "#ref:subdir" fragment, when present, takes
+	// precedence over the "?ref=" query and the path implied by the
+	// URL itself -- it's the more specific, more recently added syntax.
+	if fragRef != "" {
+		ref = fragRef
+	}
+	if fragPath != "" {
+		path = "/" + strings.TrimPrefix(fragPath, "/")
+	}
+
+	return &RepoSpec{
+		Raw:        raw,
+		Host:       host,
+		OrgRepo:    orgRepo,
+		Path:       path,
+		Ref:        ref,
+		Submodules: submodules,
+		Depth:      depth,
+		Timeout:    defaultTimeout,
+	}, nil
+}
+
+const defaultTimeout = 27 * time.Second
+
+// splitFragment strips a trailing "#ref:subdir" fragment from n, in
+// the style of a Docker build context URL, returning the remaining
+// spec along with whichever of ref and subdir were present. Either
+// half may be empty, e.g. "#:subdir" names a subdir with no ref, and
+// "#v1.2.3" names a ref with no subdir.
+func splitFragment(n string) (spec, ref, subdir string) {
+	i := strings.Index(n, "#")
+	if i < 0 {
+		return n, "", ""
+	}
+	spec = n[:i]
+	frag := n[i+1:]
+	if c := strings.Index(frag, ":"); c >= 0 {
+		return spec, frag[:c], frag[c+1:]
+	}
+	return spec, frag, ""
+}
+
+// parseQuery strips and interprets the "?ref=", "?depth=" and
+// "?submodules=" query parameters, returning the remaining spec along
+// with their parsed values. Submodules defaults to true when unset.
+func parseQuery(n string) (spec, ref string, depth int, submodules bool) {
+	submodules = true
+	i := strings.Index(n, "?")
+	if i < 0 {
+		return n, "", 0, submodules
+	}
+	spec = n[:i]
+	q, err := url.ParseQuery(n[i+1:])
+	if err != nil {
+		return spec, "", 0, submodules
+	}
+	ref = q.Get(refQuery)
+	if d := q.Get(depthQuery); d != "" {
+		if v, err := strconv.Atoi(d); err == nil {
+			depth = v
+		}
+	}
+	if s := q.Get(submQuery); s != "" {
+		if v, err := strconv.ParseBool(s); err == nil {
+			submodules = v
+		}
+	}
+	return spec, ref, depth, submodules
+}
+
+// splitHostOrgRepoPath splits n into a host (with trailing separator
+// retained), an "org/repo" pair, and the remaining in-repo path. It
+// returns an error if n is not recognizable as a git URL at all.
+func splitHostOrgRepoPath(n string) (host, orgRepo, path string, err error) {
+	rest := n
+	scheme := ""
+	for _, s := range []string{"https://", "http://", "ssh://", "git://"} {
+		if strings.HasPrefix(rest, s) {
+			scheme = s
+			rest = strings.TrimPrefix(rest, s)
+			break
+		}
+	}
+	if scheme == "" && strings.HasPrefix(rest, "git@") {
+		// scp-like syntax, e.g. git@github.com:org/repo.git
+		at := strings.Index(rest, "@")
+		colon := strings.Index(rest, ":")
+		if colon < 0 || colon < at {
+			return "", "", "", fmt.Errorf("%q: not a recognized git url", n)
+		}
+		host = rest[:colon+1]
+		rest = rest[colon+1:]
+		return finishSplit(host, rest, n)
+	}
+
+	if idx := strings.Index(rest, gitSuffix); idx >= 0 {
+		// Everything up to and including ".git" names the repo.
+		cut := idx + len(gitSuffix)
+		host, orgRepo = splitTwoSegments(scheme + rest[:cut])
+		if host == "" {
+			return "", "", "", fmt.Errorf("%q: not a recognized git url", n)
+		}
+		return host, orgRepo, rest[cut:], nil
+	}
+
+	for _, kh := range knownHosts {
+		if strings.HasPrefix(rest, kh) {
+			return finishSplit(scheme+kh, strings.TrimPrefix(rest, kh), n)
+		}
+	}
+
+	if scheme != "" {
+		// Unknown host but an explicit scheme was given: still treat it
+		// as a git URL, guessing the first path segment as the host and
+		// the next two as org/repo.
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return "", "", "", fmt.Errorf("%q: not a recognized git url", n)
+		}
+		return finishSplit(scheme+rest[:slash]+"/", rest[slash+1:], n)
+	}
+
+	return "", "", "", fmt.Errorf("%q: not a recognized git url", n)
+}
+
+// finishSplit splits rest, which follows a known host, into an org/repo
+// pair and whatever path remains.
+func finishSplit(host, rest, raw string) (string, string, string, error) {
+	segs := strings.SplitN(rest, "/", 3)
+	if len(segs) < 2 || segs[0] == "" || segs[1] == "" {
+		return "", "", "", fmt.Errorf("%q: not a recognized git url", raw)
+	}
+	org, repo := segs[0], segs[1]
+	path := ""
+	if len(segs) == 3 {
+		path = "/" + segs[2]
+	}
+	return host, org + "/" + repo, path, nil
+}
+
+// splitTwoSegments splits a "host/org/repo.git"-shaped string into a
+// trailing-slash-terminated host and an "org/repo" pair.
+func splitTwoSegments(s string) (host, orgRepo string) {
+	segs := strings.Split(s, "/")
+	if len(segs) < 3 {
+		return "", ""
+	}
+	n := len(segs)
+	return strings.Join(segs[:n-2], "/") + "/", segs[n-2] + "/" + segs[n-1]
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewRepoSpecFromURLFragmentSyntax is analogous to
+// loader.TestNewLoaderAtGitClone, but exercises NewRepoSpecFromURL
+// directly across all four combinations of ref/subdir/depth/submodules,
+// in both the Docker-style fragment form and the query form.
+func TestNewRepoSpecFromURLFragmentSyntax(t *testing.T) {
+	req := require.New(t)
+
+	testCases := []struct {
+		name        string
+		url         string
+		wantOrgRepo string
+		wantPath    string
+		wantRef     string
+		wantDepth   int
+		wantSubmod  bool
+	}{
+		{
+			name:        "none",
+			url:         "github.com/someOrg/someRepo.git",
+			wantOrgRepo: "someOrg/someRepo.git",
+			wantPath:    "",
+			wantRef:     "",
+			wantDepth:   0,
+			wantSubmod:  true,
+		},
+		{
+			name:        "ref only, fragment form",
+			url:         "github.com/someOrg/someRepo.git#v1.2.3",
+			wantOrgRepo: "someOrg/someRepo.git",
+			wantPath:    "",
+			wantRef:     "v1.2.3",
+			wantDepth:   0,
+			wantSubmod:  true,
+		},
+		{
+			name:        "ref and subdir, fragment form",
+			url:         "github.com/someOrg/someRepo.git#v1.2.3:foo/base",
+			wantOrgRepo: "someOrg/someRepo.git",
+			wantPath:    "/foo/base",
+			wantRef:     "v1.2.3",
+			wantDepth:   0,
+			wantSubmod:  true,
+		},
+		{
+			name:        "subdir only, fragment form",
+			url:         "github.com/someOrg/someRepo.git#:foo/base",
+			wantOrgRepo: "someOrg/someRepo.git",
+			wantPath:    "/foo/base",
+			wantRef:     "",
+			wantDepth:   0,
+			wantSubmod:  true,
+		},
+		{
+			name:        "ref, subdir, depth and submodules, fragment form",
+			url:         "github.com/someOrg/someRepo.git?depth=1&submodules=false#v1.2.3:foo/base",
+			wantOrgRepo: "someOrg/someRepo.git",
+			wantPath:    "/foo/base",
+			wantRef:     "v1.2.3",
+			wantDepth:   1,
+			wantSubmod:  false,
+		},
+		{
+			name:        "ref, depth and submodules, query form",
+			url:         "github.com/someOrg/someRepo/foo/base?ref=v1.2.3&depth=1&submodules=false",
+			wantOrgRepo: "someOrg/someRepo",
+			wantPath:    "/foo/base",
+			wantRef:     "v1.2.3",
+			wantDepth:   1,
+			wantSubmod:  false,
+		},
+		{
+			name:        "fragment ref overrides query ref",
+			url:         "github.com/someOrg/someRepo.git?ref=fromQuery#fromFragment",
+			wantOrgRepo: "someOrg/someRepo.git",
+			wantPath:    "",
+			wantRef:     "fromFragment",
+			wantDepth:   0,
+			wantSubmod:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := NewRepoSpecFromURL(tc.url)
+			req.NoError(err)
+			req.Equal(tc.wantOrgRepo, spec.OrgRepo)
+			req.Equal(tc.wantPath, spec.Path)
+			req.Equal(tc.wantRef, spec.Ref)
+			req.Equal(tc.wantDepth, spec.Depth)
+			req.Equal(tc.wantSubmod, spec.Submodules)
+		})
+	}
+}
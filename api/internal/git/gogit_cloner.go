@@ -0,0 +1,195 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gogitfs "github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// ClonerOptions carries the knobs that every Cloner implementation
+// should honor, beyond what's already encoded in the RepoSpec itself.
+type ClonerOptions struct {
+	// Auth, if non-nil, authenticates HTTPS or SSH transports that
+	// require it. See the go-git/v5/plumbing/transport/ssh and
+	// go-git/v5/plumbing/transport/http AuthMethod implementations.
+	Auth transport.AuthMethod
+
+	// IsolatedGitConfig, when true, asks the Cloner to ignore the
+	// user's and system's git configuration -- no .gitconfig,
+	// credential helpers, insteadOf rewrites, or core.sshCommand
+	// overrides -- so that a malicious kustomization.yaml can't use
+	// them to redirect a clone to an attacker-controlled host or leak
+	// credentials to one. Defaults to false for backward compatibility.
+	// go-git never reads user/system git config in the first place, so
+	// this is a no-op for ClonerUsingGoGit; it matters for
+	// ClonerUsingGitExec, which otherwise inherits the environment.
+	IsolatedGitConfig bool
+}
+
+// ClonerUsingGoGit returns a Cloner that clones in-process using go-git
+// instead of shelling out to a git binary, so it works without git
+// installed and gives callers control over auth via opts.Auth.
+//
+// When fSys is an in-memory filesys.FileSystem (filesys.MakeFsInMemory),
+// the clone is also done entirely in memory: nothing touches disk. For
+// any other FileSystem the clone lands in a temp directory on disk via
+// a billy/osfs adapter, same as ClonerUsingGitExec's shell-out.
+func ClonerUsingGoGit(fSys filesys.FileSystem, opts ClonerOptions) Cloner {
+	return func(spec *RepoSpec) error {
+		cloneOpts := &gogit.CloneOptions{
+			URL:               spec.CloneSpec(),
+			Auth:              opts.Auth,
+			Depth:             spec.Depth,
+			RecurseSubmodules: recurseSubmodules(spec.Submodules),
+		}
+		if isInMemoryFS(fSys) {
+			return cloneInMemory(fSys, cloneOpts, spec)
+		}
+		return cloneOnDisk(cloneOpts, spec)
+	}
+}
+
+// isInMemoryFS reports whether fSys is filesys.MakeFsInMemory's
+// implementation. filesys.FileSystem doesn't otherwise expose this, so
+// this is necessarily a little indirect; it only needs to distinguish
+// "safe to clone purely in memory" from "needs a real temp directory".
+func isInMemoryFS(fSys filesys.FileSystem) bool {
+	return strings.Contains(strings.ToLower(fmt.Sprintf("%T", fSys)), "mem")
+}
+
+func recurseSubmodules(want bool) gogit.SubmoduleRescursivity {
+	if want {
+		return gogit.DefaultSubmoduleRecursionDepth
+	}
+	return gogit.NoRecurseSubmodules
+}
+
+// inMemoryCloneSeq hands out the in-memory destination path for each
+// clone done via cloneInMemory, so that two bases cloned into the same
+// in-memory FileSystem don't collide on a shared fixed path.
+var inMemoryCloneSeq int64
+
+func nextInMemoryCloneDir() filesys.ConfirmedDir {
+	n := atomic.AddInt64(&inMemoryCloneSeq, 1)
+	return filesys.ConfirmedDir(fmt.Sprintf("/repo-%d", n))
+}
+
+// cloneInMemory clones spec entirely in memory via a billy memfs
+// worktree, checks out spec.Ref if any, then copies the resulting tree
+// into fSys -- at its own, never-reused path -- so the rest of the
+// loader can read it through the usual filesys.FileSystem calls.
+func cloneInMemory(fSys filesys.FileSystem, opts *gogit.CloneOptions, spec *RepoSpec) error {
+	wt := memfs.New()
+	repo, err := gogit.Clone(memory.NewStorage(), wt, opts)
+	if err != nil {
+		return fmt.Errorf("go-git clone of %s: %w", spec.CloneSpec(), err)
+	}
+	if spec.Ref != "" {
+		if err := checkoutRef(repo, spec.Ref); err != nil {
+			return fmt.Errorf("go-git clone of %s: %w", spec.CloneSpec(), err)
+		}
+	}
+	dst := nextInMemoryCloneDir()
+	if err := copyBillyTree(wt, "/", fSys, string(dst)); err != nil {
+		return fmt.Errorf("go-git clone of %s: %w", spec.CloneSpec(), err)
+	}
+	spec.Dir = dst
+	return nil
+}
+
+// cloneOnDisk clones spec into a temp directory on disk, using a billy
+// osfs adapter over that directory as go-git's worktree, then checks
+// out spec.Ref if any.
+func cloneOnDisk(opts *gogit.CloneOptions, spec *RepoSpec) error {
+	dir, err := filesys.NewTmpConfirmedDir()
+	if err != nil {
+		return err
+	}
+	wt := osfs.New(string(dir))
+	storer := gogitfs.NewStorage(osfs.New(string(dir)+"/.git"), nil)
+	repo, err := gogit.Clone(storer, wt, opts)
+	if err != nil {
+		return fmt.Errorf("go-git clone of %s: %w", spec.CloneSpec(), err)
+	}
+	if spec.Ref != "" {
+		if err := checkoutRef(repo, spec.Ref); err != nil {
+			return fmt.Errorf("go-git clone of %s: %w", spec.CloneSpec(), err)
+		}
+	}
+	spec.Dir = dir
+	return nil
+}
+
+// checkoutRef points repo's worktree at ref. ref may name a branch, a
+// tag, or a commit; ResolveRevision resolves all three the same way
+// `git rev-parse` would, so there's no need to guess which kind of ref
+// this is up front, unlike a plumbing.NewBranchReferenceName that only
+// ever works for branches.
+//
+// Note this still requires ref's commit to be present in whatever was
+// fetched -- a shallow (Depth > 0) clone of the default branch won't
+// contain an arbitrary tag or commit any more than the equivalent
+// `git clone --depth` would.
+func checkoutRef(repo *gogit.Repository, ref string) error {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return fmt.Errorf("resolving ref %q: %w", ref, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(&gogit.CheckoutOptions{Hash: *hash})
+}
+
+// copyBillyTree recursively copies every regular file under src in bfs
+// into fSys, rooted at dst. This is how an in-memory go-git worktree
+// ends up visible through the filesys.FileSystem interface the rest of
+// the loader already speaks.
+func copyBillyTree(bfs billy.Filesystem, src string, fSys filesys.FileSystem, dst string) error {
+	entries, err := bfs.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		srcPath := bfs.Join(src, e.Name())
+		dstPath := dst + "/" + e.Name()
+		if e.IsDir() {
+			if err := copyBillyTree(bfs, srcPath, fSys, dstPath); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := readBillyFile(bfs, srcPath)
+		if err != nil {
+			return err
+		}
+		if err := fSys.WriteFile(dstPath, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readBillyFile(bfs billy.Filesystem, path string) ([]byte, error) {
+	f, err := bfs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
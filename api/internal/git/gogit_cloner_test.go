@@ -0,0 +1,130 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// newLocalTestRepo creates a throwaway git repository on disk with two
+// commits on its default branch, a tag on the first commit, and
+// returns the repo's path and that first commit's hash.
+func newLocalTestRepo(t *testing.T) (dir string, firstCommit plumbing.Hash) {
+	t.Helper()
+	dir = t.TempDir()
+
+	repo, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	sig := &object.Signature{Name: "test", Email: "test@example.com"}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one"), 0o644))
+	_, err = wt.Add("a.txt")
+	require.NoError(t, err)
+	firstCommit, err = wt.Commit("first", &gogit.CommitOptions{Author: sig})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Storer.SetReference(
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), firstCommit)))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two"), 0o644))
+	_, err = wt.Add("a.txt")
+	require.NoError(t, err)
+	_, err = wt.Commit("second", &gogit.CommitOptions{Author: sig})
+	require.NoError(t, err)
+
+	return dir, firstCommit
+}
+
+// TestClonerUsingGoGitResolvesRefKinds checks that ClonerUsingGoGit
+// checks out the right commit for a branch, a tag, and a bare commit
+// hash alike, as well as the default branch tip when Ref is empty.
+func TestClonerUsingGoGitResolvesRefKinds(t *testing.T) {
+	dir, firstCommit := newLocalTestRepo(t)
+
+	testCases := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{name: "no ref: default branch tip", ref: "", want: "two"},
+		{name: "branch", ref: "master", want: "two"},
+		{name: "tag", ref: "v1.0.0", want: "one"},
+		{name: "commit", ref: firstCommit.String(), want: "one"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := require.New(t)
+			fSys := filesys.MakeFsInMemory()
+			spec := &RepoSpec{OrgRepo: dir, Ref: tc.ref}
+
+			req.NoError(ClonerUsingGoGit(fSys, ClonerOptions{})(spec))
+
+			data, err := fSys.ReadFile(spec.Dir.Join("a.txt"))
+			req.NoError(err)
+			req.Equal(tc.want, string(data))
+		})
+	}
+}
+
+// TestClonerUsingGoGitInMemoryDestinationsDontCollide clones the same
+// repo twice into one in-memory FileSystem and checks the two clones
+// land at different, independently readable paths.
+func TestClonerUsingGoGitInMemoryDestinationsDontCollide(t *testing.T) {
+	dir, _ := newLocalTestRepo(t)
+	req := require.New(t)
+
+	fSys := filesys.MakeFsInMemory()
+	cloner := ClonerUsingGoGit(fSys, ClonerOptions{})
+
+	spec1 := &RepoSpec{OrgRepo: dir}
+	req.NoError(cloner(spec1))
+	spec2 := &RepoSpec{OrgRepo: dir}
+	req.NoError(cloner(spec2))
+
+	req.NotEqual(spec1.Dir, spec2.Dir)
+
+	data1, err := fSys.ReadFile(spec1.Dir.Join("a.txt"))
+	req.NoError(err)
+	data2, err := fSys.ReadFile(spec2.Dir.Join("a.txt"))
+	req.NoError(err)
+	req.Equal(string(data1), string(data2))
+}
+
+// TestClonerUsingGoGitOnDisk checks the non-in-memory path: a
+// filesys.FileSystem backed by disk gets its clone in a real temp
+// directory, via the billy/osfs adapter rather than memfs.
+func TestClonerUsingGoGitOnDisk(t *testing.T) {
+	dir, _ := newLocalTestRepo(t)
+	req := require.New(t)
+
+	spec := &RepoSpec{OrgRepo: dir}
+	req.NoError(ClonerUsingGoGit(filesys.MakeFsOnDisk(), ClonerOptions{})(spec))
+	defer os.RemoveAll(string(spec.Dir))
+
+	data, err := os.ReadFile(filepath.Join(string(spec.Dir), "a.txt"))
+	req.NoError(err)
+	req.Equal("two", string(data))
+}
+
+// TestNextInMemoryCloneDirIsUnique doesn't need a real repo: it just
+// checks the counter backing every in-memory clone's destination never
+// repeats.
+func TestNextInMemoryCloneDirIsUnique(t *testing.T) {
+	req := require.New(t)
+	a := nextInMemoryCloneDir()
+	b := nextInMemoryCloneDir()
+	req.NotEqual(a, b)
+}
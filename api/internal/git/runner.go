@@ -0,0 +1,138 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// ClonerUsingGitExec is the default Cloner. It shells out to a `git`
+// binary that must be present on PATH, so it's simple but requires a
+// working git installation and, unless opts.IsolatedGitConfig is set,
+// inherits the caller's git environment (global/system .gitconfig,
+// credential helpers, etc).
+func ClonerUsingGitExec(opts ClonerOptions) Cloner {
+	return func(spec *RepoSpec) error {
+		dir, err := filesys.NewTmpConfirmedDir()
+		if err != nil {
+			return err
+		}
+		env := gitEnv(opts, dir)
+
+		// git clone --branch, which cloneArgs uses to pin a shallow
+		// clone's ref, only ever accepts a branch or tag name -- never a
+		// bare commit. A shallow clone pinned to a commit SHA needs a
+		// different recipe.
+		if spec.Depth > 0 && spec.Ref != "" && isCommitSHA(spec.Ref) {
+			return shallowCloneAtCommit(spec, dir, env)
+		}
+
+		cmd := exec.Command("git", cloneArgs(spec, dir)...)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to clone %s: %w: %s", spec.CloneSpec(), err, out)
+		}
+
+		// A shallow clone (--depth) only fetches one ref's recent
+		// history, so a ref being pinned has to be named on the clone
+		// itself via --branch, which cloneArgs already did; checking it
+		// out afterward, as a full clone does below, would fail because
+		// the commit it names was never fetched. A full clone, on the
+		// other hand, has every ref's history available, so a plain
+		// checkout after the fact works for a branch, tag, or bare
+		// commit alike.
+		if spec.Ref != "" && spec.Depth == 0 {
+			cmd = exec.Command("git", "checkout", spec.Ref)
+			cmd.Dir = string(dir)
+			cmd.Env = env
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to checkout %q in %s: %w: %s", spec.Ref, spec.CloneSpec(), err, out)
+			}
+		}
+
+		spec.Dir = dir
+		return nil
+	}
+}
+
+// cloneArgs builds the argument list for the initial `git clone` of
+// spec into dir. It assumes spec.Ref, if set alongside Depth, names a
+// branch or tag; a commit SHA goes through shallowCloneAtCommit instead.
+func cloneArgs(spec *RepoSpec, dir filesys.ConfirmedDir) []string {
+	args := []string{"clone"}
+	if spec.Depth > 0 {
+		args = append(args, "--depth", fmt.Sprintf("%d", spec.Depth))
+		if spec.Ref != "" {
+			args = append(args, "--branch", spec.Ref)
+		}
+	}
+	if spec.Submodules {
+		args = append(args, "--recurse-submodules")
+	}
+	return append(args, spec.CloneSpec(), string(dir))
+}
+
+// commitSHAPattern matches a full or abbreviated git commit hash. A
+// branch or tag name could coincidentally match it, but git resolves an
+// ambiguous name to a commit first, which is the same thing
+// shallowCloneAtCommit would do with it anyway.
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+func isCommitSHA(ref string) bool {
+	return commitSHAPattern.MatchString(ref)
+}
+
+// shallowCloneAtCommit satisfies a Depth+Ref request where Ref names a
+// bare commit rather than a branch or tag: it clones without checking
+// anything out (just to get dir set up with an "origin" remote), fetches
+// that commit specifically at the requested depth, then checks out what
+// was fetched. This is the shallow-clone equivalent of cloneArgs'
+// --branch for the branch/tag case.
+func shallowCloneAtCommit(spec *RepoSpec, dir filesys.ConfirmedDir, env []string) error {
+	run := func(args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = string(dir)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to run git %s in %s: %w: %s", args[0], spec.CloneSpec(), err, out)
+		}
+		return nil
+	}
+
+	initCmd := exec.Command("git", "clone", "--no-checkout", "--depth", "1", spec.CloneSpec(), string(dir))
+	initCmd.Env = env
+	if out, err := initCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to clone %s: %w: %s", spec.CloneSpec(), err, out)
+	}
+	if err := run("fetch", "--depth", fmt.Sprintf("%d", spec.Depth), "origin", spec.Ref); err != nil {
+		return err
+	}
+	if err := run("checkout", "FETCH_HEAD"); err != nil {
+		return err
+	}
+
+	spec.Dir = dir
+	return nil
+}
+
+// gitEnv returns the environment to run git subcommands in. With
+// opts.IsolatedGitConfig, the user's and system's git configuration is
+// disabled entirely and HOME is pointed at the clone's own temp
+// directory; see ClonerOptions.IsolatedGitConfig for why.
+func gitEnv(opts ClonerOptions, cloneDir filesys.ConfirmedDir) []string {
+	if !opts.IsolatedGitConfig {
+		return os.Environ()
+	}
+	return append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_SYSTEM=/dev/null",
+		"GIT_TERMINAL_PROMPT=0",
+		"HOME="+string(cloneDir),
+	)
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// TestCloneArgs covers the same ref/subdir/depth/submodules
+// combinations as TestNewRepoSpecFromURLFragmentSyntax, but for the
+// `git clone` invocation ClonerUsingGitExec actually builds. In
+// particular, a ref combined with a depth must be named via --branch
+// on the clone itself, since a later `git checkout` can't reach a
+// commit a shallow clone never fetched.
+func TestCloneArgs(t *testing.T) {
+	req := require.New(t)
+	dir := filesys.ConfirmedDir("/tmp/someClone")
+
+	testCases := []struct {
+		name string
+		spec *RepoSpec
+		want []string
+	}{
+		{
+			name: "none",
+			spec: &RepoSpec{Host: "github.com/", OrgRepo: "someOrg/someRepo.git", Submodules: true},
+			want: []string{"clone", "--recurse-submodules", "github.com/someOrg/someRepo.git", "/tmp/someClone"},
+		},
+		{
+			name: "ref only, no depth: left to a later checkout",
+			spec: &RepoSpec{Host: "github.com/", OrgRepo: "someOrg/someRepo.git", Ref: "v1.2.3", Submodules: true},
+			want: []string{"clone", "--recurse-submodules", "github.com/someOrg/someRepo.git", "/tmp/someClone"},
+		},
+		{
+			name: "depth only",
+			spec: &RepoSpec{Host: "github.com/", OrgRepo: "someOrg/someRepo.git", Depth: 1, Submodules: true},
+			want: []string{"clone", "--depth", "1", "--recurse-submodules", "github.com/someOrg/someRepo.git", "/tmp/someClone"},
+		},
+		{
+			name: "ref and depth: ref must be named on the clone itself",
+			spec: &RepoSpec{Host: "github.com/", OrgRepo: "someOrg/someRepo.git", Ref: "v1.2.3", Depth: 1, Submodules: true},
+			want: []string{"clone", "--depth", "1", "--branch", "v1.2.3", "--recurse-submodules", "github.com/someOrg/someRepo.git", "/tmp/someClone"},
+		},
+		{
+			name: "ref, depth and submodules off",
+			spec: &RepoSpec{Host: "github.com/", OrgRepo: "someOrg/someRepo.git", Ref: "v1.2.3", Depth: 1, Submodules: false},
+			want: []string{"clone", "--depth", "1", "--branch", "v1.2.3", "github.com/someOrg/someRepo.git", "/tmp/someClone"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req.Equal(tc.want, cloneArgs(tc.spec, dir))
+		})
+	}
+}
+
+// TestIsCommitSHA checks the branch/tag vs. commit distinction that
+// decides whether ClonerUsingGitExec can pin a shallow clone via
+// cloneArgs' --branch or needs shallowCloneAtCommit's fetch-then-
+// checkout instead.
+func TestIsCommitSHA(t *testing.T) {
+	req := require.New(t)
+
+	req.True(isCommitSHA("a1b2c3d"))
+	req.True(isCommitSHA("a1b2c3d4e5f678901234567890abcdef12345678"))
+	req.False(isCommitSHA("main"))
+	req.False(isCommitSHA("v1.2.3"))
+	req.False(isCommitSHA("release-1.0"))
+	req.False(isCommitSHA("abcd")) // too short to be an abbreviated SHA
+}
@@ -0,0 +1,22 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package konfig holds global, or widely used, kustomize configuration
+// and constants.
+package konfig
+
+// DefaultKustomizationFileName returns the default file name for a
+// kustomization file.
+func DefaultKustomizationFileName() string {
+	return "kustomization.yaml"
+}
+
+// RecognizedKustomizationFileNames returns the set of file names that
+// kustomize will recognize as a kustomization target in a directory.
+func RecognizedKustomizationFileNames() []string {
+	return []string{
+		DefaultKustomizationFileName(),
+		"kustomization.yml",
+		"Kustomization",
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package krusty has the kustomize build API.
+package krusty
+
+import (
+	"sigs.k8s.io/kustomize/api/loader"
+)
+
+// Options holds high level options for running a kustomize build.
+type Options struct {
+	// LoadRestrictions governs whether bases can be loaded from
+	// outside a kustomization's own root directory.
+	LoadRestrictions loader.RestrictionFunc
+
+	// IsolatedGitConfig, when true, runs every remote base clone with
+	// no influence from the user's or system's git configuration.
+	// Defaults to false for backward compatibility. See
+	// loader.WithIsolatedGitConfig.
+	IsolatedGitConfig bool
+}
+
+// MakeDefaultOptions returns the default Options for kustomize builds.
+func MakeDefaultOptions() *Options {
+	return &Options{
+		LoadRestrictions: loader.RestrictionRootOnly,
+	}
+}
+
+// LoaderOptions translates these Options into the loader.Option values
+// a fileLoader constructor needs to honor them.
+func (o *Options) LoaderOptions() []loader.Option {
+	var opts []loader.Option
+	if o.IsolatedGitConfig {
+		opts = append(opts, loader.WithIsolatedGitConfig())
+	}
+	return opts
+}
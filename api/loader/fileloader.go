@@ -0,0 +1,352 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package loader has a data loading interface and various implementations.
+package loader
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/ifc"
+	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/api/loader/vfs"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// fileLoader is a kustomization's interface to files. A tree of
+// fileLoaders, one per kustomization visited while building a target,
+// mirrors the tree of bases a kustomization pulls in, whether those
+// bases are plain directories, git clones, or (via vfs) archives.
+type fileLoader struct {
+	// Loader that spawned this loader via New. Nil for the loader at
+	// the root of a build.
+	referrer *fileLoader
+
+	// Absolute, cleaned path to a directory. For a loader opened on an
+	// archive base, this is vfsRoot.VFS.Root().
+	root filesys.ConfirmedDir
+
+	// Set when this loader's root came from cloning a git repo. Used
+	// to detect cycles back into an already-visited repo path, and to
+	// keep a local base reached from inside a remote overlay from
+	// escaping the clone.
+	repoSpec *git.RepoSpec
+
+	// vfsRoot, if non-nil, is the archive base this loader was opened
+	// on; Cleanup closes its VFS.
+	vfsRoot *vfs.Root
+
+	// children holds every Loader spawned from this one via New, so
+	// that Cleanup can recurse into the whole tree.
+	children []*fileLoader
+
+	// File system utilities.
+	fSys filesys.FileSystem
+
+	// Used to load http/https file and archive references.
+	http *http.Client
+
+	// Caches remote file loads across builds; nil disables caching.
+	httpCache HTTPCache
+
+	// Governs retry/timeout behavior of remote file loads.
+	httpOptions HTTPOptions
+
+	// Supplies auth for remote file loads; nil means unauthenticated.
+	credentials CredentialProvider
+
+	// Used to clone git repository bases.
+	cloner git.Cloner
+
+	// Governs what Load is allowed to read.
+	restrictionFunc RestrictionFunc
+}
+
+var _ ifc.Loader = &fileLoader{}
+
+// NewFileLoaderAtCwd returns a loader rooted at the current working
+// directory, restricted to files in or below it.
+func NewFileLoaderAtCwd(fSys filesys.FileSystem, opts ...Option) *fileLoader {
+	return newLoaderOrDie(RestrictionRootOnly, fSys, ".", opts...).(*fileLoader)
+}
+
+// NewFileLoaderAtRoot returns a loader rooted at "/", restricted to
+// files in or below it. Primarily useful with an in-memory FileSystem
+// in tests.
+func NewFileLoaderAtRoot(fSys filesys.FileSystem, opts ...Option) *fileLoader {
+	return newLoaderOrDie(RestrictionRootOnly, fSys, "/", opts...).(*fileLoader)
+}
+
+func newLoaderOrDie(
+	restrictionFunc RestrictionFunc,
+	fSys filesys.FileSystem, path string, opts ...Option) ifc.Loader {
+	root, err := filesys.ConfirmDir(fSys, path)
+	if err != nil {
+		panic(err)
+	}
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	fl := newLoaderAtConfirmedDir(
+		restrictionFunc, root, fSys, nil, o.clonerOrDefault())
+	fl.httpCache = o.httpCache
+	fl.httpOptions = o.httpOptions
+	fl.credentials = o.credentials
+	return fl
+}
+
+func newLoaderAtConfirmedDir(
+	restrictionFunc RestrictionFunc,
+	root filesys.ConfirmedDir, fSys filesys.FileSystem,
+	referrer *fileLoader, cloner git.Cloner) *fileLoader {
+	fl := &fileLoader{
+		root:            root,
+		referrer:        referrer,
+		fSys:            fSys,
+		cloner:          cloner,
+		restrictionFunc: restrictionFunc,
+		http:            &http.Client{},
+	}
+	// A loader spawned via New (referrer != nil) inherits its parent's
+	// remote-file configuration; only the loader at the root of a
+	// build gets it from Options, in newLoaderOrDie.
+	if referrer != nil {
+		fl.httpCache = referrer.httpCache
+		fl.httpOptions = referrer.httpOptions
+		fl.credentials = referrer.credentials
+	}
+	return fl
+}
+
+func newLoaderAtGitClone(
+	repoSpec *git.RepoSpec, fSys filesys.FileSystem,
+	referrer *fileLoader, cloner git.Cloner) (*fileLoader, error) {
+	if err := errIfGitCycle(referrer, repoSpec); err != nil {
+		return nil, err
+	}
+	if err := cloner(repoSpec); err != nil {
+		return nil, err
+	}
+	root, f, err := fSys.CleanedAbs(repoSpec.AbsPath())
+	if err != nil {
+		return nil, err
+	}
+	if f != "" {
+		return nil, fmt.Errorf("%q is not a directory", repoSpec.AbsPath())
+	}
+	// repoSpec.Path, whether it came from the URL's path segments or a
+	// "#ref:subdir" fragment, is attacker-controlled the moment repoSpec
+	// names a remote clone: nothing upstream of here rejects a ".."
+	// component in it. Without this check, a subdir like "../../../etc"
+	// would resolve outside the clone the same way a local base's "../"
+	// resolves outside fl.root in New -- except there's no fl.root to
+	// stay under, so it would land anywhere on disk.
+	if !root.HasPrefix(repoSpec.Dir) {
+		return nil, fmt.Errorf(
+			"got root %q outside of repo %q", root, repoSpec.Dir)
+	}
+	var parentRestriction RestrictionFunc = RestrictionRootOnly
+	if referrer != nil {
+		parentRestriction = referrer.restrictionFunc
+	}
+	fl := newLoaderAtConfirmedDir(parentRestriction, root, fSys, referrer, cloner)
+	fl.repoSpec = repoSpec
+	return fl, nil
+}
+
+// errIfGitCycle walks the referrer chain looking for a loader rooted
+// at a path in the same repo (same Host+OrgRepo) whose in-repo path
+// is equal to, a prefix of, or prefixed by, spec's -- any of which
+// means re-entering ground already visited while pulling in spec.
+func errIfGitCycle(referrer *fileLoader, spec *git.RepoSpec) error {
+	for fl := referrer; fl != nil; fl = fl.referrer {
+		if fl.repoSpec == nil ||
+			fl.repoSpec.Host != spec.Host ||
+			fl.repoSpec.OrgRepo != spec.OrgRepo {
+			continue
+		}
+		if fl.repoSpec.Path == spec.Path ||
+			strings.HasPrefix(fl.repoSpec.Path, spec.Path) ||
+			strings.HasPrefix(spec.Path, fl.repoSpec.Path) {
+			return fmt.Errorf(
+				"cycle detected: %q references already-loaded repo path %q",
+				spec.Raw, fl.repoSpec.Path)
+		}
+	}
+	return nil
+}
+
+// nearestRepoDir returns the Dir of the nearest ancestor (including fl
+// itself) that was reached via a git clone, or "" if there is none.
+func (fl *fileLoader) nearestRepoDir() filesys.ConfirmedDir {
+	for l := fl; l != nil; l = l.referrer {
+		if l.repoSpec != nil {
+			return l.repoSpec.Dir
+		}
+	}
+	return ""
+}
+
+// Repo implements ifc.Loader.
+func (fl *fileLoader) Repo() string {
+	if fl.repoSpec == nil {
+		return ""
+	}
+	return fl.repoSpec.CloneSpec()
+}
+
+// Root implements ifc.Loader.
+func (fl *fileLoader) Root() string {
+	return string(fl.root)
+}
+
+// New implements ifc.Loader. It returns a Loader for bases, which can
+// be a path relative to this Loader's root, an absolute path, a git
+// URL, or an archive reference.
+func (fl *fileLoader) New(path string) (ifc.Loader, error) {
+	if path == "" {
+		return nil, fmt.Errorf("new root cannot be empty")
+	}
+
+	if vfs.IsArchiveRef(path) {
+		root, err := fl.openArchive(path)
+		if err != nil {
+			return nil, err
+		}
+		nfl := newLoaderAtConfirmedDir(
+			fl.restrictionFunc, root.VFS.Root(), root.VFS.FileSystem(), fl, fl.cloner)
+		nfl.vfsRoot = root
+		fl.children = append(fl.children, nfl)
+		return nfl, nil
+	}
+
+	repoSpec, err := git.NewRepoSpecFromURL(path)
+	if err == nil {
+		nfl, err := newLoaderAtGitClone(repoSpec, fl.fSys, fl, fl.cloner)
+		if err != nil {
+			return nil, err
+		}
+		fl.children = append(fl.children, nfl)
+		return nfl, nil
+	}
+
+	if filepath.IsAbs(path) {
+		return nil, fmt.Errorf("new root %q cannot be absolute", path)
+	}
+
+	root := filesys.ConfirmedDir(filepath.Clean(filepath.Join(string(fl.root), path)))
+	for l := fl; l != nil; l = l.referrer {
+		if l.root == root {
+			return nil, fmt.Errorf(
+				"cycle detected: root %q already visited", root)
+		}
+	}
+	if fl.root.HasPrefix(root) {
+		return nil, fmt.Errorf(
+			"new root %q is an ancestor of current root %q", root, fl.root)
+	}
+	confirmed, err := filesys.ConfirmDir(fl.fSys, string(root))
+	if err != nil {
+		return nil, err
+	}
+	if repoDir := fl.nearestRepoDir(); repoDir != "" && !confirmed.HasPrefix(repoDir) {
+		return nil, fmt.Errorf("base '%s' is outside '%s'", confirmed, repoDir)
+	}
+	nfl := newLoaderAtConfirmedDir(
+		fl.restrictionFunc, confirmed, fl.fSys, fl, fl.cloner)
+	fl.children = append(fl.children, nfl)
+	return nfl, nil
+}
+
+// openArchive mounts path, which vfs.IsArchiveRef has already confirmed
+// looks like an archive reference, as a VFS.Root. A remote (http/https)
+// reference is fetched over fl.http, same as any other remote base. A
+// local reference is joined against fl.root and read through fl.fSys,
+// subject to fl.restrictionFunc, exactly like any other local base --
+// an archive base gets no more and no less access than a directory
+// base would.
+func (fl *fileLoader) openArchive(path string) (*vfs.Root, error) {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return vfs.OpenArchive(path, fl.http)
+	}
+	if filepath.IsAbs(path) {
+		return nil, fmt.Errorf("new root %q cannot be absolute", path)
+	}
+	root, f, err := fl.fSys.CleanedAbs(filepath.Join(string(fl.root), path))
+	if err != nil {
+		return nil, fmt.Errorf("archive base %q: %w", path, err)
+	}
+	full := root.Join(f)
+	if err := fl.restrictionFunc(fl.fSys, fl.root, full); err != nil {
+		return nil, err
+	}
+	body, err := fl.fSys.ReadFile(full)
+	if err != nil {
+		return nil, fmt.Errorf("archive base %q: %w", path, err)
+	}
+	return vfs.OpenLocalArchive(path, body)
+}
+
+// Load implements ifc.Loader.
+func (fl *fileLoader) Load(path string) ([]byte, error) {
+	if IsRemoteFile(path) {
+		return fl.loadRemoteFile(path)
+	}
+	root, f, err := fl.fSys.CleanedAbs(filepath.Join(string(fl.root), path))
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", path, err)
+	}
+	full := root.Join(f)
+	if err := fl.restrictionFunc(fl.fSys, fl.root, full); err != nil {
+		return nil, err
+	}
+	return fl.fSys.ReadFile(full)
+}
+
+// Cleanup implements ifc.Loader. It releases resources held directly
+// by this Loader -- it removes a git clone's directory from fl.fSys and
+// closes an archive base's VFS -- and recurses into every Loader spawned
+// from it via New, so that calling Cleanup once on the loader at the
+// root of a build cleans up the whole tree.
+func (fl *fileLoader) Cleanup() error {
+	var firstErr error
+	for _, child := range fl.children {
+		if err := child.Cleanup(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if fl.vfsRoot != nil {
+		if err := fl.vfsRoot.VFS.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if fl.repoSpec != nil && fl.repoSpec.Dir != "" {
+		if err := fl.fSys.RemoveAll(string(fl.repoSpec.Dir)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// IsRemoteFile returns true if path is an http(s) URL that isn't
+// recognized as a git or archive base, i.e. it names a single file to
+// fetch rather than a kustomization root.
+func IsRemoteFile(path string) bool {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		return false
+	}
+	if vfs.IsArchiveRef(path) {
+		return false
+	}
+	if _, err := git.NewRepoSpecFromURL(path); err == nil {
+		return false
+	}
+	return true
+}
+
+// loadRemoteFile itself lives in http.go, alongside the rest of the
+// caching/retry/auth subsystem it depends on.
@@ -4,6 +4,7 @@
 package loader
 
 import (
+	"archive/zip"
 	"bytes"
 	"io/ioutil"
 	"net/http"
@@ -404,6 +405,35 @@ func TestRepoDirectCycleDetection(t *testing.T) {
 	req.Contains(err.Error(), "cycle detected")
 }
 
+func TestNewLoaderAtGitCloneRejectsPathEscape(t *testing.T) {
+	req := require.New(t)
+
+	coRoot := "/tmp"
+	fSys := filesys.MakeFsInMemory()
+	fSys.MkdirAll(coRoot)
+	fSys.MkdirAll("/etc")
+
+	// The classic org/repo/sub/dir URL form.
+	repoSpec, err := git.NewRepoSpecFromURL(
+		"github.com/someOrg/someRepo/../../../etc")
+	req.NoError(err)
+	_, err = newLoaderAtGitClone(
+		repoSpec, fSys, nil,
+		git.DoNothingCloner(filesys.ConfirmedDir(coRoot)))
+	req.Error(err)
+	req.Contains(err.Error(), "outside of repo")
+
+	// The Docker-style "#ref:subdir" fragment form.
+	repoSpec, err = git.NewRepoSpecFromURL(
+		"github.com/someOrg/someRepo#:../../../etc")
+	req.NoError(err)
+	_, err = newLoaderAtGitClone(
+		repoSpec, fSys, nil,
+		git.DoNothingCloner(filesys.ConfirmedDir(coRoot)))
+	req.Error(err)
+	req.Contains(err.Error(), "outside of repo")
+}
+
 func TestRepoIndirectCycleDetection(t *testing.T) {
 	req := require.New(t)
 
@@ -508,3 +538,50 @@ func TestLoaderHTTP(t *testing.T) {
 		req.Error(err)
 	}
 }
+
+// makeZip builds an in-memory zip archive with a single entry.
+func makeZip(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// TestLoaderArchiveContainment checks that a local zip archive base is
+// subject to the same root-containment rules as any other local base:
+// it's resolved against the loader's own root and file system, and
+// RestrictionRootOnly applies to it exactly like it would to a plain
+// directory base.
+func TestLoaderArchiveContainment(t *testing.T) {
+	req := require.New(t)
+
+	zipBytes := makeZip(t, "kustomization.yaml", "whatever")
+
+	fSys := filesys.MakeFsInMemory()
+	fSys.MkdirAll("/foo/project")
+	fSys.WriteFile("/foo/project/base.zip", zipBytes)
+	fSys.WriteFile("/outside.zip", zipBytes)
+
+	l, err := NewFileLoaderAtRoot(fSys).New("foo/project")
+	req.NoError(err)
+
+	// A relative local zip base resolves against the loader's root.
+	l2, err := l.New("base.zip")
+	req.NoError(err)
+	b, err := l2.Load("kustomization.yaml")
+	req.NoError(err)
+	req.Equal([]byte("whatever"), b)
+
+	// An absolute local zip base is rejected, same as any other base.
+	_, err = l.New("/outside.zip")
+	req.Error(err)
+
+	// A local zip base cannot escape the loader's root via "..".
+	_, err = l.New("../../outside.zip")
+	req.Error(err)
+}
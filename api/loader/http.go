@@ -0,0 +1,229 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPOptions configures the retry and timeout behavior of a remote
+// file load. The zero value is usable: no retries, no timeout beyond
+// the http.Client's own.
+type HTTPOptions struct {
+	// MaxRetries is how many additional attempts are made after a
+	// request fails with a 5xx status or a network error. Zero means
+	// the request is tried exactly once.
+	MaxRetries int
+
+	// BackoffBase is the base delay for exponential backoff between
+	// retries; the n-th retry waits BackoffBase * 2^(n-1), plus a
+	// random jitter of less than BackoffBase.
+	// Defaults to 200ms if zero and MaxRetries > 0.
+	BackoffBase time.Duration
+
+	// Timeout bounds a single attempt, including any redirects. Zero
+	// means no additional timeout is applied beyond the http.Client's.
+	Timeout time.Duration
+}
+
+func (o HTTPOptions) backoffBase() time.Duration {
+	if o.BackoffBase > 0 {
+		return o.BackoffBase
+	}
+	return 200 * time.Millisecond
+}
+
+// HTTPCache lets a remote file load avoid re-fetching content that the
+// origin confirms hasn't changed, via ETag/Last-Modified validators.
+type HTTPCache interface {
+	// Get returns a previously cached response for key, if any.
+	Get(key string) (*CachedResponse, bool)
+	// Put stores resp under key, replacing anything cached there.
+	Put(key string, resp *CachedResponse) error
+}
+
+// CachedResponse is what an HTTPCache stores and validates.
+type CachedResponse struct {
+	ETag         string
+	LastModified string
+	Body         []byte
+}
+
+// CredentialProvider supplies per-host credentials for loads of
+// private raw URLs, e.g. a bearer token, HTTP basic auth, or a GitHub
+// app installation token.
+type CredentialProvider interface {
+	// Credentials returns the Authorization header value to send for
+	// requests to host, or ("", false) if this provider has nothing
+	// for that host.
+	Credentials(host string) (string, bool)
+}
+
+// BearerTokenProvider is a CredentialProvider that maps each host to
+// the bearer token sent to it.
+type BearerTokenProvider map[string]string
+
+func (p BearerTokenProvider) Credentials(host string) (string, bool) {
+	t, ok := p[host]
+	if !ok {
+		return "", false
+	}
+	return "Bearer " + t, true
+}
+
+// BasicAuthProvider is a CredentialProvider that sends HTTP basic auth
+// credentials to every host it's configured for.
+type BasicAuthProvider map[string]struct{ User, Pass string }
+
+func (p BasicAuthProvider) Credentials(host string) (string, bool) {
+	c, ok := p[host]
+	if !ok {
+		return "", false
+	}
+	raw := c.User + ":" + c.Pass
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(raw)), true
+}
+
+func (fl *fileLoader) loadRemoteFile(path string) ([]byte, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("url %q: %w", path, err)
+	}
+	var wantSum string
+	if strings.HasPrefix(u.Fragment, "sha256=") {
+		wantSum = strings.TrimPrefix(u.Fragment, "sha256=")
+	}
+
+	body, err := fl.fetchWithRetry(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if wantSum != "" {
+		sum := sha256.Sum256(body)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, wantSum) {
+			return nil, fmt.Errorf("url %q: sha256 mismatch: got %s, want %s", path, got, wantSum)
+		}
+	}
+	return body, nil
+}
+
+func (fl *fileLoader) fetchWithRetry(u *url.URL) ([]byte, error) {
+	key := u.String()
+	u.Fragment = ""
+	reqURL := u.String()
+
+	var cached *CachedResponse
+	if fl.httpCache != nil {
+		if c, ok := fl.httpCache.Get(key); ok {
+			cached = c
+		}
+	}
+
+	var lastErr error
+	attempts := fl.httpOptions.MaxRetries + 1
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryDelay(fl.httpOptions.backoffBase(), attempt))
+		}
+		res, status, err := fl.doFetch(reqURL, cached)
+		if err == nil {
+			if res.notModified {
+				return cached.Body, nil
+			}
+			if fl.httpCache != nil {
+				_ = fl.httpCache.Put(key, &CachedResponse{
+					Body:         res.body,
+					ETag:         res.etag,
+					LastModified: res.lastModified,
+				})
+			}
+			return res.body, nil
+		}
+		lastErr = err
+		if status != 0 && status < 500 {
+			// status == 0 means a network-level error (no response at
+			// all), which is worth retrying, same as a 5xx. A 4xx
+			// response won't succeed no matter how many times we ask.
+			break
+		}
+	}
+	return nil, fmt.Errorf("fetching %q: %w", reqURL, lastErr)
+}
+
+// fetchResult is what a single, successful GET attempt produces.
+type fetchResult struct {
+	body               []byte
+	etag, lastModified string
+	notModified        bool
+}
+
+// doFetch issues one GET for reqURL, adding conditional-request and
+// auth headers as configured. The returned status is 0 for a
+// transport-level error (no response at all); callers use it to
+// decide whether a retry is worthwhile.
+func (fl *fileLoader) doFetch(reqURL string, cached *CachedResponse) (fetchResult, int, error) {
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fetchResult{}, 0, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	if fl.credentials != nil {
+		if v, ok := fl.credentials.Credentials(req.URL.Host); ok {
+			req.Header.Set("Authorization", v)
+		}
+	}
+
+	client := fl.http
+	if fl.httpOptions.Timeout > 0 {
+		c := *fl.http
+		c.Timeout = fl.httpOptions.Timeout
+		client = &c
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fetchResult{}, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return fetchResult{notModified: true}, resp.StatusCode, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fetchResult{}, resp.StatusCode,
+			fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fetchResult{}, resp.StatusCode, err
+	}
+	return fetchResult{
+		body:         data,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, resp.StatusCode, nil
+}
+
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	d := base << (attempt - 1)
+	jitter := time.Duration(rand.Int63n(int64(base)))
+	return d + jitter
+}
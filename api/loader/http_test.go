@@ -0,0 +1,232 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// memHTTPCache is a minimal in-memory HTTPCache, playing the role
+// DiskHTTPCache plays in production without touching disk.
+type memHTTPCache struct {
+	mu      sync.Mutex
+	entries map[string]*CachedResponse
+}
+
+func newMemHTTPCache() *memHTTPCache {
+	return &memHTTPCache{entries: map[string]*CachedResponse{}}
+}
+
+func (c *memHTTPCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.entries[key]
+	return r, ok
+}
+
+func (c *memHTTPCache) Put(key string, resp *CachedResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = resp
+	return nil
+}
+
+var _ HTTPCache = &memHTTPCache{}
+
+func newTestLoader() *fileLoader {
+	return NewFileLoaderAtRoot(filesys.MakeFsInMemory())
+}
+
+func fakeResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		Header:     make(http.Header),
+	}
+}
+
+// TestFetchWithRetryStopsOn4xx checks that a 4xx response, which won't
+// succeed no matter how many times it's retried, is not retried.
+func TestFetchWithRetryStopsOn4xx(t *testing.T) {
+	req := require.New(t)
+
+	var attempts int
+	l := newTestLoader()
+	l.httpOptions = HTTPOptions{MaxRetries: 3, BackoffBase: time.Millisecond}
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		attempts++
+		return fakeResponse(http.StatusNotFound, "nope")
+	})
+
+	_, err := l.Load("http://example.com/missing.yaml")
+	req.Error(err)
+	req.Equal(1, attempts, "a 4xx should not be retried")
+}
+
+// TestFetchWithRetryRetries5xx checks that a 5xx response is retried,
+// and that a later attempt succeeding is what the caller sees.
+func TestFetchWithRetryRetries5xx(t *testing.T) {
+	req := require.New(t)
+
+	var attempts int
+	l := newTestLoader()
+	l.httpOptions = HTTPOptions{MaxRetries: 2, BackoffBase: time.Millisecond}
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		attempts++
+		if attempts < 3 {
+			return fakeResponse(http.StatusServiceUnavailable, "try again")
+		}
+		return fakeResponse(http.StatusOK, "eventually ok")
+	})
+
+	b, err := l.Load("http://example.com/flaky.yaml")
+	req.NoError(err)
+	req.Equal("eventually ok", string(b))
+	req.Equal(3, attempts)
+}
+
+// TestFetchWithRetryExhaustsRetriesOn5xx checks that retries stop after
+// MaxRetries and the caller gets an error, rather than retrying forever.
+func TestFetchWithRetryExhaustsRetriesOn5xx(t *testing.T) {
+	req := require.New(t)
+
+	var attempts int
+	l := newTestLoader()
+	l.httpOptions = HTTPOptions{MaxRetries: 2, BackoffBase: time.Millisecond}
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		attempts++
+		return fakeResponse(http.StatusServiceUnavailable, "down")
+	})
+
+	_, err := l.Load("http://example.com/down.yaml")
+	req.Error(err)
+	req.Equal(3, attempts) // initial attempt + 2 retries
+}
+
+// TestFetchWithRetryServesCachedBodyOn304 checks that a 304 response
+// causes the cached body to be served, with the conditional-request
+// headers set from what was cached.
+func TestFetchWithRetryServesCachedBodyOn304(t *testing.T) {
+	req := require.New(t)
+
+	cache := newMemHTTPCache()
+	cache.entries["http://example.com/cached.yaml"] = &CachedResponse{
+		ETag: `"abc"`,
+		Body: []byte("cached content"),
+	}
+
+	l := newTestLoader()
+	l.httpCache = cache
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		req.Equal(`"abc"`, r.Header.Get("If-None-Match"))
+		return fakeResponse(http.StatusNotModified, "")
+	})
+
+	b, err := l.Load("http://example.com/cached.yaml")
+	req.NoError(err)
+	req.Equal("cached content", string(b))
+}
+
+// TestFetchWithRetryUpdatesCacheOnFreshResponse checks that a normal
+// 200 response gets stored in the cache for a later conditional
+// request to validate against.
+func TestFetchWithRetryUpdatesCacheOnFreshResponse(t *testing.T) {
+	req := require.New(t)
+
+	cache := newMemHTTPCache()
+	l := newTestLoader()
+	l.httpCache = cache
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		resp := fakeResponse(http.StatusOK, "fresh content")
+		resp.Header.Set("ETag", `"xyz"`)
+		return resp
+	})
+
+	b, err := l.Load("http://example.com/fresh.yaml")
+	req.NoError(err)
+	req.Equal("fresh content", string(b))
+
+	cached, ok := cache.Get("http://example.com/fresh.yaml")
+	req.True(ok)
+	req.Equal(`"xyz"`, cached.ETag)
+	req.Equal("fresh content", string(cached.Body))
+}
+
+// TestLoadRemoteFileSha256Mismatch checks that a "#sha256=..." fragment
+// that doesn't match the fetched body's actual sum is rejected.
+func TestLoadRemoteFileSha256Mismatch(t *testing.T) {
+	req := require.New(t)
+
+	l := newTestLoader()
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		return fakeResponse(http.StatusOK, "actual content")
+	})
+
+	_, err := l.Load("http://example.com/file.yaml#sha256=deadbeef")
+	req.Error(err)
+	req.Contains(err.Error(), "sha256 mismatch")
+}
+
+// TestDoFetchInjectsCredentials checks that a configured
+// CredentialProvider's Authorization header actually reaches the
+// request.
+func TestDoFetchInjectsCredentials(t *testing.T) {
+	req := require.New(t)
+
+	l := newTestLoader()
+	l.credentials = BearerTokenProvider{"example.com": "s3cr3t"}
+	l.http = makeFakeHTTPClient(func(r *http.Request) *http.Response {
+		req.Equal("Bearer s3cr3t", r.Header.Get("Authorization"))
+		return fakeResponse(http.StatusOK, "secret content")
+	})
+
+	b, err := l.Load("http://example.com/private.yaml")
+	req.NoError(err)
+	req.Equal("secret content", string(b))
+}
+
+// TestBasicAuthProviderCredentials checks BasicAuthProvider's header
+// value directly, independent of any HTTP round trip.
+func TestBasicAuthProviderCredentials(t *testing.T) {
+	req := require.New(t)
+
+	p := BasicAuthProvider{"example.com": {User: "alice", Pass: "hunter2"}}
+	v, ok := p.Credentials("example.com")
+	req.True(ok)
+	req.Equal("Basic YWxpY2U6aHVudGVyMg==", v)
+
+	_, ok = p.Credentials("other.com")
+	req.False(ok)
+}
+
+// TestDiskHTTPCacheRoundTrip checks that DiskHTTPCache actually
+// persists and retrieves entries across instances, via the directory
+// on disk rather than any in-process state.
+func TestDiskHTTPCacheRoundTrip(t *testing.T) {
+	req := require.New(t)
+
+	dir := t.TempDir()
+	c1, err := NewDiskHTTPCache(dir)
+	req.NoError(err)
+
+	_, ok := c1.Get("http://example.com/a.yaml")
+	req.False(ok)
+
+	want := &CachedResponse{ETag: `"abc"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT", Body: []byte("content")}
+	req.NoError(c1.Put("http://example.com/a.yaml", want))
+
+	c2, err := NewDiskHTTPCache(dir)
+	req.NoError(err)
+	got, ok := c2.Get("http://example.com/a.yaml")
+	req.True(ok)
+	req.Equal(want, got)
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// DiskHTTPCache is an HTTPCache backed by a directory on disk. Each
+// cached response is one JSON file, named after the sha256 of its key
+// (the full request URL, fragment included) so that a "sha256="
+// fragment naturally partitions cache entries per expected content.
+type DiskHTTPCache struct {
+	dir string
+}
+
+// NewDiskHTTPCache returns a DiskHTTPCache rooted at dir, creating dir
+// if it doesn't already exist.
+func NewDiskHTTPCache(dir string) (*DiskHTTPCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskHTTPCache{dir: dir}, nil
+}
+
+var _ HTTPCache = &DiskHTTPCache{}
+
+func (c *DiskHTTPCache) Get(key string) (*CachedResponse, bool) {
+	data, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, false
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (c *DiskHTTPCache) Put(key string, resp *CachedResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.entryPath(key), data, 0o644)
+}
+
+func (c *DiskHTTPCache) entryPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"sigs.k8s.io/kustomize/api/internal/git"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// Option configures a fileLoader constructed by NewFileLoaderAtCwd or
+// NewFileLoaderAtRoot.
+type Option func(*options)
+
+type options struct {
+	useGoGit          bool
+	goGitFSys         filesys.FileSystem
+	goGitOpts         git.ClonerOptions
+	isolatedGitConfig bool
+
+	httpCache   HTTPCache
+	httpOptions HTTPOptions
+	credentials CredentialProvider
+}
+
+// WithHTTPCache makes the loader validate and reuse remote file loads
+// across builds via cache, keyed on URL (including any "sha256="
+// fragment). Nil (the default) disables caching.
+func WithHTTPCache(cache HTTPCache) Option {
+	return func(o *options) {
+		o.httpCache = cache
+	}
+}
+
+// WithHTTPOptions configures retry/backoff/timeout behavior for remote
+// file loads. The zero value (the default) means no retries and no
+// extra timeout beyond the http.Client's own.
+func WithHTTPOptions(opts HTTPOptions) Option {
+	return func(o *options) {
+		o.httpOptions = opts
+	}
+}
+
+// WithCredentialProvider supplies per-host auth for remote file loads,
+// e.g. so a private raw URL can be used as a base. Nil (the default)
+// sends no Authorization header.
+func WithCredentialProvider(p CredentialProvider) Option {
+	return func(o *options) {
+		o.credentials = p
+	}
+}
+
+// WithGoGitCloner makes the loader clone git bases in-process via
+// go-git instead of shelling out to a git binary. This is hermetic
+// (no git installation required) and, for an in-memory FileSystem,
+// entirely avoids touching disk. The shell-based cloner remains the
+// default when this option isn't supplied.
+func WithGoGitCloner(fSys filesys.FileSystem, opts git.ClonerOptions) Option {
+	return func(o *options) {
+		o.useGoGit = true
+		o.goGitFSys = fSys
+		o.goGitOpts = opts
+	}
+}
+
+// WithIsolatedGitConfig makes every remote base clone run with no
+// influence from the user's or system's git configuration: no
+// .gitconfig, no credential helpers, no insteadOf rewrites, and no
+// core.sshCommand overrides. Off by default for backward
+// compatibility. Honored by both the shell-exec cloner and, where
+// relevant, the go-git cloner; see git.ClonerOptions.IsolatedGitConfig.
+func WithIsolatedGitConfig() Option {
+	return func(o *options) {
+		o.isolatedGitConfig = true
+	}
+}
+
+func (o *options) clonerOrDefault() git.Cloner {
+	if o.useGoGit {
+		opts := o.goGitOpts
+		opts.IsolatedGitConfig = o.isolatedGitConfig
+		return git.ClonerUsingGoGit(o.goGitFSys, opts)
+	}
+	return git.ClonerUsingGitExec(git.ClonerOptions{
+		IsolatedGitConfig: o.isolatedGitConfig,
+	})
+}
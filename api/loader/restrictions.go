@@ -0,0 +1,35 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// RestrictionFunc decides whether a file at the given (already
+// symlink-resolved) path may be read given the loader's root.
+type RestrictionFunc func(fSys filesys.FileSystem, root filesys.ConfirmedDir, path string) error
+
+// RestrictionRootOnly requires that every file loaded through a given
+// Loader, and every file it's a symlink to, be in or below that
+// Loader's root. This is the default, and the only thing standing
+// between a kustomization and a base that reaches out of its own tree
+// (e.g. via "../../../etc/passwd" or a symlink planted for that
+// purpose).
+func RestrictionRootOnly(_ filesys.FileSystem, root filesys.ConfirmedDir, path string) error {
+	dir := filesys.ConfirmedDir(filepath.Dir(path))
+	if !dir.HasPrefix(root) {
+		return fmt.Errorf(
+			"security; file '%s' is not in or below '%s'", path, root)
+	}
+	return nil
+}
+
+// RestrictionNone disables the root-containment check entirely.
+func RestrictionNone(filesys.FileSystem, filesys.ConfirmedDir, string) error {
+	return nil
+}
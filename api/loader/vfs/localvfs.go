@@ -0,0 +1,23 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package vfs
+
+import "sigs.k8s.io/kustomize/kyaml/filesys"
+
+// localVFS wraps an already-materialized directory -- a plain directory
+// on disk or in memory, or the checkout left behind by a git.Cloner --
+// as a VFS. It owns no resources of its own, so Close is a no-op.
+type localVFS struct {
+	fSys filesys.FileSystem
+	root filesys.ConfirmedDir
+}
+
+// NewLocalRoot wraps an already-confirmed directory as a Root.
+func NewLocalRoot(fSys filesys.FileSystem, root filesys.ConfirmedDir) *Root {
+	return &Root{VFS: &localVFS{fSys: fSys, root: root}}
+}
+
+func (v *localVFS) FileSystem() filesys.FileSystem { return v.fSys }
+func (v *localVFS) Root() filesys.ConfirmedDir     { return v.root }
+func (v *localVFS) Close() error                   { return nil }
@@ -0,0 +1,32 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package vfs defines a minimal virtual file system abstraction used by
+// the loader package to mount a kustomization base -- a local directory,
+// a git clone, or a downloaded archive -- behind a single read-only root.
+package vfs
+
+import "sigs.k8s.io/kustomize/kyaml/filesys"
+
+// VFS is a read-only view onto the contents of a mounted base.
+// Implementations translate whatever storage backs the base into the
+// filesys.FileSystem calls the loader already knows how to make, so the
+// loader itself stays oblivious to where a base actually came from.
+type VFS interface {
+	// FileSystem returns the filesys.FileSystem to read files through.
+	FileSystem() filesys.FileSystem
+
+	// Root returns the confirmed, absolute directory within
+	// FileSystem() that this VFS exposes as its root.
+	Root() filesys.ConfirmedDir
+
+	// Close releases any resources (temp directories, open archives)
+	// held by this VFS. It is safe to call Close more than once.
+	Close() error
+}
+
+// Root is the result of opening a base: the VFS it is mounted as. The
+// kustomization root is the VFS's own Root(); no sub-path is kept.
+type Root struct {
+	VFS VFS
+}